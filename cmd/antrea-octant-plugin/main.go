@@ -15,20 +15,26 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"math"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/vmware-tanzu/octant/pkg/icon"
 	"github.com/vmware-tanzu/octant/pkg/navigation"
 	"github.com/vmware-tanzu/octant/pkg/plugin"
 	"github.com/vmware-tanzu/octant/pkg/plugin/service"
 	"github.com/vmware-tanzu/octant/pkg/view/component"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/tools/clientcmd"
 
+	clusterinformationv1beta1 "github.com/vmware-tanzu/antrea/pkg/apis/clusterinformation/v1beta1"
 	clientset "github.com/vmware-tanzu/antrea/pkg/client/clientset/versioned"
+	"github.com/vmware-tanzu/antrea/pkg/version"
 )
 
 var (
@@ -38,6 +44,10 @@ var (
 
 const (
 	kubeConfig      = "KUBECONFIG"
+	kubeAPIQPS      = "KUBE_API_QPS"
+	kubeAPIBurst    = "KUBE_API_BURST"
+	defaultQPS      = 20
+	defaultBurst    = 40
 	title           = "Antrea Information"
 	controllerTitle = "Antrea Controller Info"
 	agentTitle      = "Antrea Agent Info"
@@ -50,15 +60,35 @@ const (
 	bridgeCol       = "OVS Bridge"
 	podNumCol       = "Local Pod Num"
 	heartbeatCol    = "Last Heartbeat Time"
+	healthTitle     = "Antrea Controller Health"
+	versionTitle    = "Antrea Octant Plugin Version"
+	notAvailable    = "N/A"
+
+	// The Controller updates its heartbeat every minute, so allow a few missed updates before
+	// reporting it as unhealthy.
+	heartbeatTimeout = 3 * time.Minute
+	colorHealthy     = "#60b515"
+	colorUnhealthy   = "#e12200"
 )
 
 func main() {
 	// Remove the prefix from the go logger since Octant will print logs with timestamps.
 	log.SetPrefix("")
-	config, err := clientcmd.BuildConfigFromFlags("", os.Getenv(kubeConfig))
+	kubeConfigPath := os.Getenv(kubeConfig)
+	if kubeConfigPath == "" {
+		log.Printf("%s is not set, falling back to in-cluster config", kubeConfig)
+	} else if err := validateKubeConfig(kubeConfigPath); err != nil {
+		log.Fatal(err)
+	}
+	config, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
 	if err != nil {
-		log.Fatalf("Failed to build kubeConfig %v", err)
+		if kubeConfigPath == "" {
+			log.Fatalf("Failed to build in-cluster kubeConfig, set %s if the plugin is not running in a Pod: %v", kubeConfig, err)
+		}
+		log.Fatalf("Failed to build kubeConfig from %s %v", kubeConfigPath, err)
 	}
+	config.QPS, config.Burst = getClientRateLimits()
+	log.Printf("K8s client rate limits: QPS %v, Burst %d (override with %s and %s)", config.QPS, config.Burst, kubeAPIQPS, kubeAPIBurst)
 	client, err = clientset.NewForConfig(config)
 	if err != nil {
 		log.Fatalf("Failed to create K8s client for antrea-octant-plugin %v", err)
@@ -85,10 +115,52 @@ func main() {
 		log.Fatal(err)
 	}
 
-	log.Printf("antrea-octant-plugin is starting")
+	log.Printf("antrea-octant-plugin (version %s) is starting", version.GetFullVersion())
 	p.Serve()
 }
 
+// validateKubeConfig checks that the kubeconfig file exists and is readable, so that a
+// misconfigured path is reported clearly instead of through a client-go error.
+func validateKubeConfig(path string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%s file %s not found; set %s to a valid kubeconfig file or unset it to run in-cluster", kubeConfig, path, kubeConfig)
+	} else if err != nil {
+		return fmt.Errorf("failed to access %s file %s: %v", kubeConfig, path, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s %s is a directory; set %s to a kubeconfig file", kubeConfig, path, kubeConfig)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("%s file %s is not readable: %v", kubeConfig, path, err)
+	}
+	f.Close()
+	return nil
+}
+
+// getClientRateLimits returns the QPS and Burst used by the K8s client, read from
+// environment variables and falling back to defaults when they are unset or invalid.
+func getClientRateLimits() (float32, int) {
+	qps := float32(defaultQPS)
+	if v := os.Getenv(kubeAPIQPS); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 32); err == nil && parsed > 0 && !math.IsInf(parsed, 0) {
+			qps = float32(parsed)
+		} else {
+			log.Printf("Ignoring invalid %s value %q, using default %d", kubeAPIQPS, v, defaultQPS)
+		}
+	}
+	burst := defaultBurst
+	if v := os.Getenv(kubeAPIBurst); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			burst = parsed
+		} else {
+			log.Printf("Ignoring invalid %s value %q, using default %d", kubeAPIBurst, v, defaultBurst)
+		}
+	}
+	return qps, burst
+}
+
 // handleNavigation generates contents displayed on navigation bar and their paths.
 func handleNavigation(request *service.NavigationRequest) (navigation.Navigation, error) {
 	return navigation.Navigation{
@@ -105,6 +177,11 @@ func handleNavigation(request *service.NavigationRequest) (navigation.Navigation
 				Path:     request.GeneratePath("components/agent"),
 				IconName: "folder",
 			},
+			{
+				Title:    versionTitle,
+				Path:     request.GeneratePath("version"),
+				IconName: "folder",
+			},
 		},
 		IconName: "cloud",
 	}, nil
@@ -117,14 +194,15 @@ func initRoutes(router *service.Router) {
 
 	// Click on navigation bar named Antrea Information to display Antrea components (both Controller and Agent) information.
 	router.HandleFunc("/components", func(request *service.Request) (component.ContentResponse, error) {
-		controllerRows := getControllerRows()
-		agentRows := getAgentRows()
+		// Each section is rendered independently, so that a failure to list one CRD does not hide
+		// the information already available from the other.
+		controllers, controllersErr := listControllers()
+		agents, agentsErr := listAgents()
+		components := append(getControllerSection(controllers, controllersErr, controllerCols),
+			getAgentSection(agents, agentsErr, agentCols)...)
 		return component.ContentResponse{
-			Title: component.TitleFromString(title),
-			Components: []component.Component{
-				component.NewTableWithRows(controllerTitle, "", controllerCols, controllerRows),
-				component.NewTableWithRows(agentTitle, "", agentCols, agentRows),
-			},
+			Title:      component.TitleFromString(title),
+			Components: components,
 			IconName:   "cloud",
 			IconSource: "cloud",
 		}, nil
@@ -132,12 +210,10 @@ func initRoutes(router *service.Router) {
 
 	// Click on navigation child named Antrea Controller Info to display Controller information.
 	router.HandleFunc("/components/controller", func(request *service.Request) (component.ContentResponse, error) {
-		controllerRows := getControllerRows()
+		controllers, err := listControllers()
 		return component.ContentResponse{
-			Title: component.TitleFromString(controllerTitle),
-			Components: []component.Component{
-				component.NewTableWithRows(controllerTitle, "", controllerCols, controllerRows),
-			},
+			Title:      component.TitleFromString(controllerTitle),
+			Components: getControllerSection(controllers, err, controllerCols),
 			IconName:   icon.OverviewDeployment,
 			IconSource: icon.OverviewDeployment,
 		}, nil
@@ -145,26 +221,92 @@ func initRoutes(router *service.Router) {
 
 	// Click on navigation child named Antrea Agent Info to display Agent information.
 	router.HandleFunc("/components/agent", func(request *service.Request) (component.ContentResponse, error) {
-		agentRows := getAgentRows()
+		agents, err := listAgents()
 		return component.ContentResponse{
-			Title: component.TitleFromString(agentTitle),
-			Components: []component.Component{
-				component.NewTableWithRows(agentTitle, "", agentCols, agentRows),
-			},
+			Title:      component.TitleFromString(agentTitle),
+			Components: getAgentSection(agents, err, agentCols),
 			IconName:   icon.OverviewDaemonSet,
 			IconSource: icon.OverviewDaemonSet,
 		}, nil
 	})
+
+	// Click on navigation child named Antrea Octant Plugin Version to display the plugin build version
+	// and the Antrea API version it reads, for diagnostics.
+	router.HandleFunc("/version", func(request *service.Request) (component.ContentResponse, error) {
+		return component.ContentResponse{
+			Title: component.TitleFromString(versionTitle),
+			Components: []component.Component{
+				getVersionSummary(),
+			},
+			IconName:   icon.ConfigurationPlugin,
+			IconSource: icon.ConfigurationPlugin,
+		}, nil
+	})
 }
 
-// getControllerRows gets rows for displaying Controller information
-func getControllerRows() []component.TableRow {
+// getVersionSummary returns a summary of the plugin version, which is set at build time through
+// ldflags, and of the Antrea API group version used by the plugin.
+func getVersionSummary() component.Component {
+	return component.NewSummary(versionTitle,
+		component.SummarySection{Header: "Plugin Version", Content: component.NewText(version.GetFullVersion())},
+		component.SummarySection{Header: "Antrea API Version", Content: component.NewText(clusterinformationv1beta1.SchemeGroupVersion.String())},
+	)
+}
+
+// newErrorCard builds the component displayed in place of a section that failed to render, so that
+// all failures are rendered as the same error card instead of raw error strings.
+func newErrorCard(sectionTitle string, err error) component.Component {
+	log.Printf("Failed to render %s: %v", sectionTitle, err)
+	return component.NewError(component.TitleFromString(sectionTitle), err)
+}
+
+// getControllerSection returns the Controller health stat and table, or an error card if listing
+// AntreaControllerInfos failed.
+func getControllerSection(controllers []clusterinformationv1beta1.AntreaControllerInfo, err error,
+	cols []component.TableCol) []component.Component {
+	if err != nil {
+		return []component.Component{newErrorCard(controllerTitle, err)}
+	}
+	return []component.Component{
+		getControllerHealth(controllers),
+		component.NewTableWithRows(controllerTitle, "", cols, getControllerRows(controllers)),
+	}
+}
+
+// getAgentSection returns the Agent table, or an error card if listing AntreaAgentInfos failed.
+func getAgentSection(agents []clusterinformationv1beta1.AntreaAgentInfo, err error,
+	cols []component.TableCol) []component.Component {
+	if err != nil {
+		return []component.Component{newErrorCard(agentTitle, err)}
+	}
+	return []component.Component{
+		component.NewTableWithRows(agentTitle, "", cols, getAgentRows(agents)),
+	}
+}
+
+// listControllers lists AntreaControllerInfos once per request, so that all components on a page
+// are built from the same items.
+func listControllers() ([]clusterinformationv1beta1.AntreaControllerInfo, error) {
 	controllers, err := client.ClusterinformationV1beta1().AntreaControllerInfos().List(v1.ListOptions{})
 	if err != nil {
-		log.Fatalf("Failed to get AntreaControllerInfos %v", err)
+		return nil, fmt.Errorf("failed to get AntreaControllerInfos: %v", err)
+	}
+	return controllers.Items, nil
+}
+
+// listAgents lists AntreaAgentInfos once per request.
+func listAgents() ([]clusterinformationv1beta1.AntreaAgentInfo, error) {
+	agents, err := client.ClusterinformationV1beta1().AntreaAgentInfos().List(v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AntreaAgentInfos: %v", err)
 	}
+	return agents.Items, nil
+}
+
+// getControllerRows gets rows for displaying Controller information
+func getControllerRows(controllers []clusterinformationv1beta1.AntreaControllerInfo) []component.TableRow {
 	controllerRows := make([]component.TableRow, 0)
-	for _, controller := range controllers.Items {
+	for _, controller := range controllers {
 		controllerRows = append(controllerRows, component.TableRow{
 			versionCol: component.NewText(controller.Version),
 			podCol: component.NewLink(controller.PodRef.Name, controller.PodRef.Name,
@@ -175,32 +317,97 @@ func getControllerRows() []component.TableRow {
 				"/overview/namespace/"+controller.PodRef.Namespace+"/discovery-and-load-balancing/services/"+controller.ServiceRef.Name),
 			crdCol: component.NewLink(controller.Name, controller.Name,
 				"/cluster-overview/custom-resources/antreacontrollerinfos.clusterinformation.antrea.tanzu.vmware.com/"+controller.Name),
-			heartbeatCol: component.NewText(controller.ControllerConditions[0].LastHeartbeatTime.String()),
+			heartbeatCol: component.NewText(getControllerHeartbeat(controller.ControllerConditions)),
 		})
 	}
 	return controllerRows
 }
 
-// getAgentRows gets table rows for displaying Agent information.
-func getAgentRows() []component.TableRow {
-	agents, err := client.ClusterinformationV1beta1().AntreaAgentInfos().List(v1.ListOptions{})
-	if err != nil {
-		log.Fatalf("Failed to get AntreaAgentInfos %v", err)
+// getControllerHealth returns a stat showing whether a healthy Antrea Controller is running, i.e.
+// whether its ControllerHealthy condition is True and it has sent a heartbeat recently.
+func getControllerHealth(controllers []clusterinformationv1beta1.AntreaControllerInfo) component.Component {
+	for _, controller := range controllers {
+		if isControllerHealthy(&controller) {
+			return component.NewSingleStat(healthTitle, "Healthy", colorHealthy)
+		}
+	}
+	return component.NewSingleStat(healthTitle, "Unhealthy", colorUnhealthy)
+}
+
+// isControllerHealthy checks the ControllerHealthy condition of an AntreaControllerInfo.
+func isControllerHealthy(controller *clusterinformationv1beta1.AntreaControllerInfo) bool {
+	condition := getControllerCondition(controller.ControllerConditions, clusterinformationv1beta1.ControllerHealthy)
+	if condition == nil {
+		return false
 	}
+	return condition.Status == corev1.ConditionTrue && time.Since(condition.LastHeartbeatTime.Time) < heartbeatTimeout
+}
+
+// getNodeSubnet returns the first subnet of the Node, or N/A if the Agent has not reported any.
+func getNodeSubnet(subnets []string) string {
+	if len(subnets) == 0 {
+		return notAvailable
+	}
+	return subnets[0]
+}
+
+// getControllerCondition returns the condition of the given type, or nil if it is not reported.
+// Conditions are omitempty, so a freshly created or partially populated CR may have none.
+func getControllerCondition(conditions []clusterinformationv1beta1.ControllerCondition,
+	conditionType clusterinformationv1beta1.ControllerConditionType) *clusterinformationv1beta1.ControllerCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// getAgentCondition returns the condition of the given type, or nil if it is not reported.
+func getAgentCondition(conditions []clusterinformationv1beta1.AgentCondition,
+	conditionType clusterinformationv1beta1.AgentConditionType) *clusterinformationv1beta1.AgentCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// getControllerHeartbeat returns the heartbeat time of the ControllerHealthy condition, or N/A.
+func getControllerHeartbeat(conditions []clusterinformationv1beta1.ControllerCondition) string {
+	condition := getControllerCondition(conditions, clusterinformationv1beta1.ControllerHealthy)
+	if condition == nil {
+		return notAvailable
+	}
+	return condition.LastHeartbeatTime.String()
+}
+
+// getAgentHeartbeat returns the heartbeat time of the AgentHealthy condition, or N/A.
+func getAgentHeartbeat(conditions []clusterinformationv1beta1.AgentCondition) string {
+	condition := getAgentCondition(conditions, clusterinformationv1beta1.AgentHealthy)
+	if condition == nil {
+		return notAvailable
+	}
+	return condition.LastHeartbeatTime.String()
+}
+
+// getAgentRows gets table rows for displaying Agent information.
+func getAgentRows(agents []clusterinformationv1beta1.AntreaAgentInfo) []component.TableRow {
 	agentRows := make([]component.TableRow, 0)
-	for _, agent := range agents.Items {
+	for _, agent := range agents {
 		agentRows = append(agentRows, component.TableRow{
 			versionCol: component.NewText(agent.Version),
 			podCol: component.NewLink(agent.PodRef.Name, agent.PodRef.Name,
 				"/overview/namespace/"+agent.PodRef.Namespace+"/workloads/pods/"+agent.PodRef.Name),
 			nodeCol: component.NewLink(agent.NodeRef.Name, agent.NodeRef.Name,
 				"/cluster-overview/nodes/"+agent.NodeRef.Name),
-			subnetCol: component.NewText(agent.NodeSubnet[0]),
+			subnetCol: component.NewText(getNodeSubnet(agent.NodeSubnet)),
 			bridgeCol: component.NewText(agent.OVSInfo.BridgeName),
 			podNumCol: component.NewText(strconv.Itoa(int(agent.LocalPodNum))),
 			crdCol: component.NewLink(agent.Name, agent.Name,
 				"/cluster-overview/custom-resources/antreaagentinfos.clusterinformation.antrea.tanzu.vmware.com/"+agent.Name),
-			heartbeatCol: component.NewText(agent.AgentConditions[0].LastHeartbeatTime.String()),
+			heartbeatCol: component.NewText(getAgentHeartbeat(agent.AgentConditions)),
 		})
 	}
 	return agentRows
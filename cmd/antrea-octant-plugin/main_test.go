@@ -0,0 +1,269 @@
+// Copyright 2020 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware-tanzu/octant/pkg/view/component"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterinformationv1beta1 "github.com/vmware-tanzu/antrea/pkg/apis/clusterinformation/v1beta1"
+)
+
+// setEnv sets the environment variable, or unsets it if value is empty, and returns a function
+// restoring its previous state.
+func setEnv(key, value string) func() {
+	oldValue, existed := os.LookupEnv(key)
+	if value == "" {
+		_ = os.Unsetenv(key)
+	} else {
+		_ = os.Setenv(key, value)
+	}
+	return func() {
+		if existed {
+			_ = os.Setenv(key, oldValue)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	}
+}
+
+func TestGetClientRateLimits(t *testing.T) {
+	tests := []struct {
+		name          string
+		qps           string
+		burst         string
+		expectedQPS   float32
+		expectedBurst int
+	}{
+		{"unset", "", "", defaultQPS, defaultBurst},
+		{"valid", "12.5", "30", 12.5, 30},
+		{"zero", "0", "0", defaultQPS, defaultBurst},
+		{"negative", "-5", "-10", defaultQPS, defaultBurst},
+		{"non-numeric", "fast", "many", defaultQPS, defaultBurst},
+		{"infinite QPS", "Inf", "", defaultQPS, defaultBurst},
+		{"positive infinite QPS", "+Inf", "", defaultQPS, defaultBurst},
+		{"NaN QPS", "NaN", "", defaultQPS, defaultBurst},
+		{"valid QPS only", "100", "abc", 100, defaultBurst},
+		{"valid Burst only", "-1", "200", defaultQPS, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer setEnv(kubeAPIQPS, tt.qps)()
+			defer setEnv(kubeAPIBurst, tt.burst)()
+			qps, burst := getClientRateLimits()
+			assert.Equal(t, tt.expectedQPS, qps)
+			assert.Equal(t, tt.expectedBurst, burst)
+		})
+	}
+}
+
+func TestGetControllerHeartbeat(t *testing.T) {
+	heartbeat := v1.NewTime(time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name       string
+		conditions []clusterinformationv1beta1.ControllerCondition
+		expected   string
+	}{
+		{"nil conditions", nil, notAvailable},
+		{"empty conditions", []clusterinformationv1beta1.ControllerCondition{}, notAvailable},
+		{
+			"no ControllerHealthy condition",
+			[]clusterinformationv1beta1.ControllerCondition{{Type: "Unknown", LastHeartbeatTime: heartbeat}},
+			notAvailable,
+		},
+		{
+			"ControllerHealthy condition",
+			[]clusterinformationv1beta1.ControllerCondition{
+				{Type: "Unknown"},
+				{Type: clusterinformationv1beta1.ControllerHealthy, LastHeartbeatTime: heartbeat},
+			},
+			heartbeat.String(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, getControllerHeartbeat(tt.conditions))
+		})
+	}
+}
+
+func TestGetAgentHeartbeat(t *testing.T) {
+	heartbeat := v1.NewTime(time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name       string
+		conditions []clusterinformationv1beta1.AgentCondition
+		expected   string
+	}{
+		{"nil conditions", nil, notAvailable},
+		{"empty conditions", []clusterinformationv1beta1.AgentCondition{}, notAvailable},
+		{
+			"no AgentHealthy condition",
+			[]clusterinformationv1beta1.AgentCondition{
+				{Type: clusterinformationv1beta1.OVSDBConnectionUp, LastHeartbeatTime: heartbeat},
+			},
+			notAvailable,
+		},
+		{
+			"AgentHealthy condition",
+			[]clusterinformationv1beta1.AgentCondition{
+				{Type: clusterinformationv1beta1.ControllerConnectionUp},
+				{Type: clusterinformationv1beta1.AgentHealthy, LastHeartbeatTime: heartbeat},
+			},
+			heartbeat.String(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, getAgentHeartbeat(tt.conditions))
+		})
+	}
+}
+
+func TestIsControllerHealthy(t *testing.T) {
+	recent := v1.NewTime(time.Now().Add(-time.Minute))
+	stale := v1.NewTime(time.Now().Add(-heartbeatTimeout - time.Minute))
+	tests := []struct {
+		name       string
+		conditions []clusterinformationv1beta1.ControllerCondition
+		expected   bool
+	}{
+		{
+			"healthy with recent heartbeat",
+			[]clusterinformationv1beta1.ControllerCondition{
+				{Type: clusterinformationv1beta1.ControllerHealthy, Status: corev1.ConditionTrue, LastHeartbeatTime: recent},
+			},
+			true,
+		},
+		{
+			"stale heartbeat",
+			[]clusterinformationv1beta1.ControllerCondition{
+				{Type: clusterinformationv1beta1.ControllerHealthy, Status: corev1.ConditionTrue, LastHeartbeatTime: stale},
+			},
+			false,
+		},
+		{
+			"status False",
+			[]clusterinformationv1beta1.ControllerCondition{
+				{Type: clusterinformationv1beta1.ControllerHealthy, Status: corev1.ConditionFalse, LastHeartbeatTime: recent},
+			},
+			false,
+		},
+		{
+			"no ControllerHealthy condition",
+			[]clusterinformationv1beta1.ControllerCondition{
+				{Type: "Unknown", Status: corev1.ConditionTrue, LastHeartbeatTime: recent},
+			},
+			false,
+		},
+		{"no conditions", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := &clusterinformationv1beta1.AntreaControllerInfo{ControllerConditions: tt.conditions}
+			assert.Equal(t, tt.expected, isControllerHealthy(controller))
+		})
+	}
+}
+
+func TestGetRowsWithMissingFields(t *testing.T) {
+	// Only the metadata is set, as for a CR created before the Controller or Agent reported its
+	// status, or by a version that omits these fields.
+	controllers := []clusterinformationv1beta1.AntreaControllerInfo{{ObjectMeta: v1.ObjectMeta{Name: "antrea-controller"}}}
+	agents := []clusterinformationv1beta1.AntreaAgentInfo{{ObjectMeta: v1.ObjectMeta{Name: "node-1"}}}
+
+	controllerRows := getControllerRows(controllers)
+	assert.Len(t, controllerRows, 1)
+	assert.Equal(t, component.NewText(notAvailable), controllerRows[0][heartbeatCol])
+
+	agentRows := getAgentRows(agents)
+	assert.Len(t, agentRows, 1)
+	assert.Equal(t, component.NewText(notAvailable), agentRows[0][subnetCol])
+	assert.Equal(t, component.NewText(notAvailable), agentRows[0][heartbeatCol])
+}
+
+func TestValidateKubeConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "antrea-octant-plugin-test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	validPath := filepath.Join(dir, "kubeconfig")
+	assert.NoError(t, ioutil.WriteFile(validPath, []byte("apiVersion: v1\nkind: Config\n"), 0600))
+
+	tests := []struct {
+		name        string
+		path        string
+		expectedErr bool
+	}{
+		{"missing file", filepath.Join(dir, "missing"), true},
+		{"directory", dir, true},
+		{"valid file", validPath, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateKubeConfig(tt.path)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("unreadable file", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("Permission bits are not enforced for root")
+		}
+		unreadablePath := filepath.Join(dir, "unreadable")
+		assert.NoError(t, ioutil.WriteFile(unreadablePath, []byte{}, 0000))
+		assert.Error(t, validateKubeConfig(unreadablePath))
+	})
+}
+
+func TestGetSections(t *testing.T) {
+	controllerCols := component.NewTableCols(versionCol, heartbeatCol)
+	agentCols := component.NewTableCols(versionCol, subnetCol)
+	controllers := []clusterinformationv1beta1.AntreaControllerInfo{{ObjectMeta: v1.ObjectMeta{Name: "antrea-controller"}}}
+	agents := []clusterinformationv1beta1.AntreaAgentInfo{{ObjectMeta: v1.ObjectMeta{Name: "node-1"}}}
+	listErr := errors.New("connection refused")
+
+	controllerSection := getControllerSection(controllers, nil, controllerCols)
+	assert.Len(t, controllerSection, 2)
+	assert.IsType(t, &component.SingleStat{}, controllerSection[0])
+	assert.IsType(t, &component.Table{}, controllerSection[1])
+
+	agentSection := getAgentSection(agents, nil, agentCols)
+	assert.Len(t, agentSection, 1)
+	assert.IsType(t, &component.Table{}, agentSection[0])
+
+	// A failure in one section is rendered as an error card in its place only, and the other
+	// section is built independently.
+	failedAgentSection := getAgentSection(nil, listErr, agentCols)
+	assert.Len(t, failedAgentSection, 1)
+	assert.Equal(t, newErrorCard(agentTitle, listErr), failedAgentSection[0])
+
+	failedControllerSection := getControllerSection(nil, listErr, controllerCols)
+	assert.Len(t, failedControllerSection, 1)
+	assert.Equal(t, newErrorCard(controllerTitle, listErr), failedControllerSection[0])
+}